@@ -0,0 +1,244 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Conflict policies for bidirectionalSync, selected via
+// SyncConfig.ConflictPolicy.
+const (
+	conflictNewer  = "newer"
+	conflictLarger = "larger"
+	conflictLocal  = "local"
+	conflictRemote = "remote"
+	conflictRename = "rename"
+)
+
+// bidirectionalSync reconciles LocalPath and RemotePath: files that
+// exist on only one side are copied to the other, and files that exist
+// on both but differ are resolved per ConflictPolicy.
+func (s *Syncer) bidirectionalSync() error {
+	s.logger.Info("Performing bidirectional synchronization...")
+
+	localFiles, err := s.collectLocalFiles()
+	if err != nil {
+		return fmt.Errorf("failed to collect local files: %v", err)
+	}
+	if err := s.hashFiles(localFiles); err != nil {
+		return fmt.Errorf("failed to hash local files: %v", err)
+	}
+
+	remoteFiles, err := s.collectRemoteFiles()
+	if err != nil {
+		return fmt.Errorf("failed to collect remote files: %v", err)
+	}
+
+	manifest, err := s.loadRemoteManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load remote manifest: %v", err)
+	}
+
+	toPush, toPull, toRename := s.planBidirectional(localFiles, remoteFiles, manifest)
+
+	pushSet := append(dirsOf(localFiles), toPush...)
+	pullSet := append(dirsOf(remoteFiles), toPull...)
+
+	if s.dryRun {
+		s.logger.Infof("Dry run (bidirectional): %d to push, %d to pull, %d conflict copies", countNonDirs(pushSet), countNonDirs(pullSet), len(toRename))
+		return s.logDryRun("bidirectional", append(append([]FileInfo{}, pushSet...), pullSet...))
+	}
+
+	s.totalSize = 0
+	for _, file := range pushSet {
+		if !file.IsDir {
+			s.totalSize += file.Size
+		}
+	}
+	for _, file := range pullSet {
+		if !file.IsDir {
+			s.totalSize += file.Size
+		}
+	}
+	for _, file := range toRename {
+		s.totalSize += file.Size
+	}
+
+	s.prepareBar()
+
+	if err := os.MkdirAll(s.localPath, 0755); err != nil {
+		s.finishBar()
+		return fmt.Errorf("failed to create local directory: %v", err)
+	}
+	if err := s.sftpClient().MkdirAll(s.remotePath); err != nil {
+		s.finishBar()
+		return fmt.Errorf("failed to create remote directory: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 2)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := s.uploadFiles(pushSet, s.remotePath); err != nil {
+			errCh <- fmt.Errorf("push failed: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := s.downloadFiles(pullSet); err != nil {
+			errCh <- fmt.Errorf("pull failed: %v", err)
+		}
+	}()
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil {
+			s.finishBar()
+			return err
+		}
+	}
+
+	bufPool := &sync.Pool{New: func() interface{} { return make([]byte, 32*1024) }}
+	for _, remote := range toRename {
+		if err := s.downloadConflictCopy(remote, bufPool); err != nil {
+			s.finishBar()
+			return fmt.Errorf("failed to save conflicting copy of %s: %v", remote.RelPath, err)
+		}
+	}
+
+	s.finishBar()
+
+	syncedFiles, err := s.collectLocalFiles()
+	if err != nil {
+		return fmt.Errorf("failed to re-collect local files for manifest update: %v", err)
+	}
+	if err := s.hashFiles(syncedFiles); err != nil {
+		return fmt.Errorf("failed to hash local files for manifest update: %v", err)
+	}
+	manifest.update(syncedFiles)
+	if err := s.saveRemoteManifest(manifest); err != nil {
+		return fmt.Errorf("failed to save remote manifest: %v", err)
+	}
+
+	return nil
+}
+
+// planBidirectional classifies every relative path present on either
+// side into a push list, a pull list, or (under the "rename" conflict
+// policy) a conflict-copy list, per ConflictPolicy. A path with a
+// manifest entry is only treated as unchanged when its local content
+// hash and the remote's size/mtime both still match that entry (see
+// unchangedSinceManifest for paths without one);
+// relying on local size+mtime alone (as a prior version of this code
+// did) misses content drift on either side that kept the same mtime.
+func (s *Syncer) planBidirectional(localFiles, remoteFiles []FileInfo, manifest *Manifest) (toPush, toPull, toRename []FileInfo) {
+	localByRel := make(map[string]FileInfo, len(localFiles))
+	for _, file := range localFiles {
+		if !file.IsDir {
+			localByRel[file.RelPath] = file
+		}
+	}
+
+	remoteByRel := make(map[string]FileInfo, len(remoteFiles))
+	for _, file := range remoteFiles {
+		if !file.IsDir {
+			remoteByRel[file.RelPath] = file
+		}
+	}
+
+	seen := make(map[string]bool, len(localByRel))
+	for relPath, local := range localByRel {
+		seen[relPath] = true
+
+		remote, existsRemote := remoteByRel[relPath]
+		if !existsRemote {
+			toPush = append(toPush, local)
+			continue
+		}
+
+		if unchangedSinceManifest(local, remote, manifest) {
+			continue
+		}
+
+		switch s.resolveConflict(local, remote) {
+		case "push":
+			toPush = append(toPush, local)
+		case "pull":
+			toPull = append(toPull, remote)
+		case "rename":
+			toRename = append(toRename, remote)
+		}
+	}
+
+	for relPath, remote := range remoteByRel {
+		if !seen[relPath] {
+			toPull = append(toPull, remote)
+		}
+	}
+
+	return toPush, toPull, toRename
+}
+
+// unchangedSinceManifest reports whether a path present on both sides
+// still matches the state recorded the last time bidirectionalSync
+// synced it: the local content hash and the remote size/mtime must all
+// agree with the manifest entry. Without a manifest entry (e.g. the
+// first bidirectional run for this path), it falls back to comparing
+// local and remote size/mtime directly.
+func unchangedSinceManifest(local, remote FileInfo, manifest *Manifest) bool {
+	entry, known := manifest.Files[local.RelPath]
+	if !known {
+		return local.Size == remote.Size && local.ModTime.Equal(remote.ModTime)
+	}
+
+	return local.Hash == entry.Hash &&
+		remote.Size == entry.Size &&
+		remote.ModTime.Equal(entry.ModTime)
+}
+
+// resolveConflict decides which side wins for a path present, and
+// different, on both sides.
+func (s *Syncer) resolveConflict(local, remote FileInfo) string {
+	switch s.conflictPolicy {
+	case conflictLocal:
+		return "push"
+	case conflictRemote:
+		return "pull"
+	case conflictRename:
+		return "rename"
+	case conflictLarger:
+		if local.Size >= remote.Size {
+			return "push"
+		}
+		return "pull"
+	default: // conflictNewer
+		if local.ModTime.After(remote.ModTime) {
+			return "push"
+		}
+		return "pull"
+	}
+}
+
+// downloadConflictCopy saves the remote side of a "rename" conflict
+// next to the existing local file, rather than overwriting it.
+func (s *Syncer) downloadConflictCopy(remote FileInfo, bufPool *sync.Pool) error {
+	renamed := remote
+	renamed.RelPath = fmt.Sprintf("%s.conflict-remote-%s", remote.RelPath, time.Now().Format("20060102_150405"))
+	return s.downloadFile(renamed, bufPool)
+}
+
+// dirsOf returns the directory entries of files.
+func dirsOf(files []FileInfo) []FileInfo {
+	var dirs []FileInfo
+	for _, file := range files {
+		if file.IsDir {
+			dirs = append(dirs, file)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,125 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// checkpointFlushInterval is how many bytes of progress accumulate on a
+// file before its checkpoint offset is persisted to disk.
+const checkpointFlushInterval = 1 * 1024 * 1024
+
+// Checkpoint tracks upload progress for one sync run, keyed by the
+// sync's host/LocalPath/RemotePath triple (see computeSyncID), so an
+// interrupted run can resume on the next invocation.
+type Checkpoint struct {
+	SyncID  string           `json:"sync_id"`
+	Offsets map[string]int64 `json:"offsets"`
+
+	dir string
+	mu  sync.Mutex
+}
+
+// computeSyncID derives a stable identifier for a host/LocalPath/
+// RemotePath triple, used to name its checkpoint file. host
+// disambiguates a MultiSyncer's fan-out targets, which otherwise share
+// the same LocalPath/RemotePath and would race on one checkpoint file.
+func computeSyncID(host, localPath, remotePath string) string {
+	sum := sha256.Sum256([]byte(host + "|" + localPath + "|" + remotePath))
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkpointPath resolves the on-disk location of a checkpoint,
+// defaulting to ~/.fileuploader/state when dir is empty.
+func checkpointPath(dir, syncID string) (string, error) {
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %v", err)
+		}
+		dir = filepath.Join(home, ".fileuploader", "state")
+	}
+	return filepath.Join(dir, syncID+".json"), nil
+}
+
+// loadCheckpoint reads the checkpoint for syncID from dir, returning an
+// empty checkpoint if none exists yet.
+func loadCheckpoint(dir, syncID string) (*Checkpoint, error) {
+	path, err := checkpointPath(dir, syncID)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &Checkpoint{SyncID: syncID, Offsets: make(map[string]int64), dir: dir}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	if cp.Offsets == nil {
+		cp.Offsets = make(map[string]int64)
+	}
+	cp.dir = dir
+
+	return cp, nil
+}
+
+// set records relPath's confirmed offset and persists the checkpoint.
+func (c *Checkpoint) set(relPath string, offset int64) error {
+	c.mu.Lock()
+	c.Offsets[relPath] = offset
+	c.mu.Unlock()
+	return c.save()
+}
+
+// clear drops relPath's entry (the file finished uploading) and
+// persists the checkpoint.
+func (c *Checkpoint) clear(relPath string) error {
+	c.mu.Lock()
+	delete(c.Offsets, relPath)
+	c.mu.Unlock()
+	return c.save()
+}
+
+// offset returns the last confirmed offset for relPath, or 0.
+func (c *Checkpoint) offset(relPath string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Offsets[relPath]
+}
+
+// save writes the checkpoint to disk.
+func (c *Checkpoint) save() error {
+	path, err := checkpointPath(c.dir, c.SyncID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %v", err)
+	}
+
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+
+	return nil
+}
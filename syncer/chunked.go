@@ -0,0 +1,202 @@
+package syncer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultChunkConcurrency is used when SyncConfig.ChunkConcurrency is
+// unset but ChunkedUploadThreshold is.
+const defaultChunkConcurrency = 4
+
+// chunkedWriteBufSize is the read/write buffer size used by each chunk
+// worker within its range.
+const chunkedWriteBufSize = 32 * 1024
+
+// byteRange is a half-open [start, start+length) span of a file.
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+// chunkResult is one writeChunk outcome: how many bytes it managed to
+// write before succeeding or failing.
+type chunkResult struct {
+	written int64
+	err     error
+}
+
+// chunkedUploadFile uploads a single large file by pre-allocating the
+// remote file and writing N byte ranges in parallel via WriteAt. It
+// falls back to the sequential uploadFile path if the remote doesn't
+// support pre-allocation or parallel WriteAt.
+func (s *Syncer) chunkedUploadFile(file FileInfo, remoteBasePath string, bufPool *sync.Pool) error {
+	remoteFilePath := filepath.Join(remoteBasePath, file.RelPath)
+	remoteDir := filepath.Dir(remoteFilePath)
+
+	if err := s.sftpClient().MkdirAll(remoteDir); err != nil {
+		return fmt.Errorf("failed to create remote directory: %v", err)
+	}
+
+	if err := s.preallocateRemoteFile(remoteFilePath, file.Size); err != nil {
+		s.logger.Warnf("Remote does not support pre-allocation for %s, falling back to sequential upload: %v", file.RelPath, err)
+		return s.uploadFile(file, remoteBasePath, bufPool)
+	}
+
+	ranges := splitRanges(file.Size, s.chunkConcurrency)
+
+	var wg sync.WaitGroup
+	results := make(chan chunkResult, len(ranges))
+	for _, r := range ranges {
+		wg.Add(1)
+		go func(r byteRange) {
+			defer wg.Done()
+			written, err := s.writeChunk(file.Path, remoteFilePath, r)
+			results <- chunkResult{written: written, err: err}
+		}(r)
+	}
+	wg.Wait()
+	close(results)
+
+	var totalWritten int64
+	var firstErr error
+	for res := range results {
+		totalWritten += res.written
+		if res.err != nil && firstErr == nil {
+			firstErr = res.err
+		}
+	}
+
+	if firstErr != nil {
+		s.logger.Warnf("Parallel chunk write failed for %s, falling back to sequential upload: %v", file.RelPath, firstErr)
+
+		// The partial parallel writes above already counted toward the
+		// bar; undo that before uploadFile re-sends the whole file, or
+		// the bar would double-count these bytes.
+		s.mutex.Lock()
+		s.syncedSize -= totalWritten
+		s.bar.SetCurrent(s.syncedSize)
+		s.mutex.Unlock()
+
+		return s.uploadFile(file, remoteBasePath, bufPool)
+	}
+
+	if err := s.sftpClient().Chmod(remoteFilePath, 0644); err != nil {
+		s.logger.Warnf("Failed to set permissions for %s: %v", remoteFilePath, err)
+	}
+
+	if err := s.sftpClient().Chtimes(remoteFilePath, time.Now(), file.ModTime); err != nil {
+		s.logger.Warnf("Failed to set modification time for %s: %v", remoteFilePath, err)
+	}
+
+	s.logger.Debugf("Chunked-uploaded: %s (%d bytes, %d chunks)", file.RelPath, file.Size, len(ranges))
+	return nil
+}
+
+// preallocateRemoteFile creates (or truncates) the remote file and
+// sizes it to its final length, so concurrent WriteAt calls never race
+// on extending it.
+func (s *Syncer) preallocateRemoteFile(remoteFilePath string, size int64) error {
+	remoteFile, err := s.sftpClient().OpenFile(remoteFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file: %v", err)
+	}
+	defer remoteFile.Close()
+
+	if err := remoteFile.Truncate(size); err != nil {
+		return fmt.Errorf("failed to pre-allocate remote file: %v", err)
+	}
+
+	return nil
+}
+
+// writeChunk streams one byte range from the local file to the remote
+// file using WriteAt, updating shared upload progress as it goes. It
+// returns how many bytes it wrote even when it returns an error, so
+// the caller can undo that much from the shared progress bar before
+// falling back to a sequential re-upload.
+func (s *Syncer) writeChunk(localPath, remoteFilePath string, r byteRange) (int64, error) {
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer localFile.Close()
+
+	remoteFile, err := s.sftpClient().OpenFile(remoteFilePath, os.O_WRONLY)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer remoteFile.Close()
+
+	buf := make([]byte, chunkedWriteBufSize)
+	offset := r.start
+	remaining := r.length
+	var written int64
+
+	for remaining > 0 {
+		want := int64(len(buf))
+		if remaining < want {
+			want = remaining
+		}
+
+		n, err := localFile.ReadAt(buf[:want], offset)
+		if n > 0 {
+			if _, writeErr := remoteFile.WriteAt(buf[:n], offset); writeErr != nil {
+				return written, fmt.Errorf("failed WriteAt offset %d: %v", offset, writeErr)
+			}
+
+			offset += int64(n)
+			remaining -= int64(n)
+			written += int64(n)
+
+			s.mutex.Lock()
+			s.syncedSize += int64(n)
+			s.bar.SetCurrent(s.syncedSize)
+			s.mutex.Unlock()
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return written, fmt.Errorf("failed ReadAt offset %d: %v", offset, err)
+		}
+	}
+
+	return written, nil
+}
+
+// splitRanges divides size into up to n roughly-equal byte ranges.
+func splitRanges(size int64, n int) []byteRange {
+	if n <= 0 {
+		n = defaultChunkConcurrency
+	}
+
+	chunkSize := size / int64(n)
+	if chunkSize == 0 {
+		chunkSize = size
+		n = 1
+	}
+
+	ranges := make([]byteRange, 0, n)
+	offset := int64(0)
+
+	for i := 0; i < n; i++ {
+		length := chunkSize
+		if i == n-1 {
+			length = size - offset
+		}
+		if length <= 0 {
+			break
+		}
+
+		ranges = append(ranges, byteRange{start: offset, length: length})
+		offset += length
+	}
+
+	return ranges
+}
@@ -7,6 +7,12 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// Config is the top-level YAML configuration for a sync run.
+type Config struct {
+	SSH  SSHConfig
+	Sync SyncConfig
+}
+
 // LoadConfig loads configuration from a YAML file
 func LoadConfig(filename string) (Config, error) {
 	var config Config
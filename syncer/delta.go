@@ -0,0 +1,318 @@
+package syncer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultDeltaBlockSize is used when SyncConfig.DeltaBlockSize is unset.
+const defaultDeltaBlockSize = 64 * 1024
+
+// blockSignature describes one fixed-size block of an existing remote
+// file: a cheap rolling checksum for a first-pass match, confirmed by a
+// strong hash before a block is trusted as unchanged.
+type blockSignature struct {
+	Index  int
+	Weak   uint32
+	Strong [sha256.Size]byte
+	Size   int64
+}
+
+// deltaOp is one instruction in the plan to reconstruct a file: either
+// copy an unchanged block from the existing remote file, or send a
+// literal range of bytes read from the local file.
+type deltaOp struct {
+	copy   bool
+	index  int   // remote block index, when copy is true
+	offset int64 // local file offset, when copy is false
+	length int64
+}
+
+// deltaUploadFile updates a single remote file using rsync-style delta
+// transfer: unchanged blocks are copied from the existing remote file
+// and only the literal, changed ranges are read from the local file,
+// into a temp remote file that then replaces the target. It falls back to a whole-file upload when the
+// remote file is missing or too small for delta to pay off.
+func (s *Syncer) deltaUploadFile(file FileInfo, remoteBasePath string, bufPool *sync.Pool) error {
+	remoteFilePath := filepath.Join(remoteBasePath, file.RelPath)
+	remoteDir := filepath.Dir(remoteFilePath)
+
+	if err := s.sftpClient().MkdirAll(remoteDir); err != nil {
+		return fmt.Errorf("failed to create remote directory: %v", err)
+	}
+
+	remoteInfo, err := s.sftpClient().Stat(remoteFilePath)
+	if err != nil || remoteInfo.Size() < 2*s.deltaBlockSize {
+		s.logger.Debugf("Delta skipped for %s, falling back to whole-file upload", file.RelPath)
+		return s.uploadFile(file, remoteBasePath, bufPool)
+	}
+
+	sigs, err := s.computeRemoteSignatures(remoteFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to compute remote signatures: %v", err)
+	}
+
+	ops, err := planDeltaOps(file.Path, sigs, s.deltaBlockSize)
+	if err != nil {
+		return fmt.Errorf("failed to plan delta ops: %v", err)
+	}
+
+	if err := s.applyDeltaOps(file, remoteFilePath, ops); err != nil {
+		return fmt.Errorf("failed to apply delta ops: %v", err)
+	}
+
+	if err := s.sftpClient().Chmod(remoteFilePath, 0644); err != nil {
+		s.logger.Warnf("Failed to set permissions for %s: %v", remoteFilePath, err)
+	}
+
+	if err := s.sftpClient().Chtimes(remoteFilePath, time.Now(), file.ModTime); err != nil {
+		s.logger.Warnf("Failed to set modification time for %s: %v", remoteFilePath, err)
+	}
+
+	s.logger.Debugf("Delta-uploaded: %s (%d bytes, %d ops)", file.RelPath, file.Size, len(ops))
+	return nil
+}
+
+// computeRemoteSignatures reads the existing remote file over SFTP and
+// computes a weak+strong checksum for each fixed-size block.
+func (s *Syncer) computeRemoteSignatures(remoteFilePath string) ([]blockSignature, error) {
+	remoteFile, err := s.sftpClient().Open(remoteFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer remoteFile.Close()
+
+	var sigs []blockSignature
+	buf := make([]byte, s.deltaBlockSize)
+
+	for index := 0; ; index++ {
+		n, err := io.ReadFull(remoteFile, buf)
+		if n > 0 {
+			block := buf[:n]
+			sigs = append(sigs, blockSignature{
+				Index:  index,
+				Weak:   rollingChecksum(block),
+				Strong: sha256.Sum256(block),
+				Size:   int64(n),
+			})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read remote file: %v", err)
+		}
+	}
+
+	return sigs, nil
+}
+
+// planDeltaOps slides a byte window over the local file, looking up
+// each window's rolling checksum against the remote block signatures.
+// A weak match is confirmed with the strong hash before the window is
+// accepted as a block copy; everything else becomes literal data. The
+// file is streamed through a small ring buffer, and the weak checksum
+// is rolled incrementally (subtract the byte leaving the window, add
+// the byte entering it) rather than recomputed from scratch per byte.
+func planDeltaOps(localPath string, sigs []blockSignature, blockSize int64) ([]deltaOp, error) {
+	byWeak := make(map[uint32][]blockSignature, len(sigs))
+	for _, sig := range sigs {
+		byWeak[sig.Weak] = append(byWeak[sig.Weak], sig)
+	}
+
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer localFile.Close()
+
+	reader := bufio.NewReaderSize(localFile, 256*1024)
+
+	n := int(blockSize)
+	ring := make([]byte, n)
+	ringStart, ringLen := 0, 0
+	var a, b uint32
+
+	var ops []deltaOp
+	literalStart, pos := int64(0), int64(0)
+
+	flushLiteral := func(end int64) {
+		if end > literalStart {
+			ops = append(ops, deltaOp{offset: literalStart, length: end - literalStart})
+		}
+	}
+
+	windowBytes := func() []byte {
+		out := make([]byte, ringLen)
+		for i := 0; i < ringLen; i++ {
+			out[i] = ring[(ringStart+i)%n]
+		}
+		return out
+	}
+
+	// resetWindow drops the current window, e.g. right after a matched
+	// block, so the next window starts fresh rather than overlapping it.
+	resetWindow := func() {
+		ringStart, ringLen = 0, 0
+		a, b = 0, 0
+	}
+
+	// tryMatch confirms the current window's weak checksum with a
+	// strong hash against the remote block signatures.
+	tryMatch := func() (blockSignature, bool) {
+		candidates, ok := byWeak[combineWeak(a, b)]
+		if !ok {
+			return blockSignature{}, false
+		}
+		window := windowBytes()
+		strong := sha256.Sum256(window)
+		for _, candidate := range candidates {
+			if candidate.Size == int64(len(window)) && candidate.Strong == strong {
+				return candidate, true
+			}
+		}
+		return blockSignature{}, false
+	}
+
+	for {
+		c, readErr := reader.ReadByte()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read local file: %v", readErr)
+		}
+		pos++
+
+		if ringLen < n {
+			ring[ringLen] = c
+			ringLen++
+			a += uint32(c)
+			b += uint32(n-ringLen+1) * uint32(c)
+		} else {
+			oldest := ring[ringStart]
+			a = a - uint32(oldest) + uint32(c)
+			b = b - uint32(n)*uint32(oldest) + a
+			ring[ringStart] = c
+			ringStart = (ringStart + 1) % n
+		}
+
+		if ringLen == n {
+			if match, ok := tryMatch(); ok {
+				flushLiteral(pos - int64(ringLen))
+				ops = append(ops, deltaOp{copy: true, index: match.Index, length: match.Size})
+				literalStart = pos
+				resetWindow()
+			}
+		}
+	}
+
+	// The file may end mid-window; the remainder can still match the
+	// remote's own final (possibly short) block.
+	if ringLen > 0 {
+		// The fill-time sums weight bytes for a full block; recompute them
+		// for the window's actual length, as weakSums does remotely.
+		a, b = weakSums(windowBytes())
+		if match, ok := tryMatch(); ok {
+			flushLiteral(pos - int64(ringLen))
+			ops = append(ops, deltaOp{copy: true, index: match.Index, length: match.Size})
+			literalStart = pos
+		}
+	}
+
+	flushLiteral(pos)
+	return ops, nil
+}
+
+// applyDeltaOps executes the delta plan by writing a new temp remote
+// file, copying unchanged blocks from the existing remote file and the
+// literal ranges from the local file, then atomically renaming it over
+// the target.
+func (s *Syncer) applyDeltaOps(file FileInfo, remoteFilePath string, ops []deltaOp) error {
+	localFile, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer localFile.Close()
+
+	existingRemote, err := s.sftpClient().Open(remoteFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open existing remote file: %v", err)
+	}
+	defer existingRemote.Close()
+
+	tempRemotePath := remoteFilePath + ".delta_tmp_" + time.Now().Format("20060102_150405")
+	tempRemote, err := s.sftpClient().Create(tempRemotePath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp remote file: %v", err)
+	}
+
+	for _, op := range ops {
+		if op.copy {
+			if _, err := existingRemote.Seek(int64(op.index)*s.deltaBlockSize, io.SeekStart); err != nil {
+				tempRemote.Close()
+				return fmt.Errorf("failed to seek remote block %d: %v", op.index, err)
+			}
+			if _, err := io.CopyN(tempRemote, existingRemote, op.length); err != nil {
+				tempRemote.Close()
+				return fmt.Errorf("failed to copy remote block %d: %v", op.index, err)
+			}
+		} else {
+			if _, err := localFile.Seek(op.offset, io.SeekStart); err != nil {
+				tempRemote.Close()
+				return fmt.Errorf("failed to seek local offset %d: %v", op.offset, err)
+			}
+			if _, err := io.CopyN(tempRemote, localFile, op.length); err != nil {
+				tempRemote.Close()
+				return fmt.Errorf("failed to write literal range: %v", err)
+			}
+		}
+
+		s.mutex.Lock()
+		s.syncedSize += op.length
+		s.bar.SetCurrent(s.syncedSize)
+		s.mutex.Unlock()
+	}
+
+	if err := tempRemote.Close(); err != nil {
+		return fmt.Errorf("failed to close temp remote file: %v", err)
+	}
+
+	if err := s.sftpClient().Rename(tempRemotePath, remoteFilePath); err != nil {
+		return fmt.Errorf("failed to rename temp remote file into place: %v", err)
+	}
+
+	return nil
+}
+
+// weakSums computes the two running sums behind the rolling checksum:
+// a is the plain byte sum, b is the position-weighted sum used to
+// roll the checksum forward one byte at a time without rescanning the
+// whole window.
+func weakSums(block []byte) (a, b uint32) {
+	n := uint32(len(block))
+	for i, c := range block {
+		a += uint32(c)
+		b += (n - uint32(i)) * uint32(c)
+	}
+	return a, b
+}
+
+// combineWeak folds the two rolling-checksum sums into the 32-bit weak
+// checksum value used to index blockSignatures.
+func combineWeak(a, b uint32) uint32 {
+	return (b << 16) | (a & 0xffff)
+}
+
+// rollingChecksum computes the classic rsync-style weak checksum
+// (Adler-32-like two-sum construction) over block.
+func rollingChecksum(block []byte) uint32 {
+	a, b := weakSums(block)
+	return combineWeak(a, b)
+}
@@ -0,0 +1,283 @@
+package syncer
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultIgnoreFile is used when SyncConfig.IgnoreFile is unset.
+const defaultIgnoreFile = ".syncignore"
+
+// filterRule is one compiled .gitignore-style pattern.
+type filterRule struct {
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	base     string   // dir (relative to LocalPath) the rule applies under; "" for root
+	segments []string // anchored pattern split on "/", used by includeCouldMatchDir
+	re       *regexp.Regexp
+}
+
+// matches reports whether relPath (always "/"-separated, relative to
+// LocalPath) is matched by this rule.
+func (r *filterRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	rel := relPath
+	if r.base != "" {
+		prefix := r.base + "/"
+		if !strings.HasPrefix(relPath, prefix) {
+			return false
+		}
+		rel = strings.TrimPrefix(relPath, prefix)
+	}
+
+	if r.anchored {
+		return r.re.MatchString(rel)
+	}
+
+	// Unanchored patterns may match at any path segment, like gitignore.
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if r.re.MatchString(strings.Join(parts[i:], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileFilterRule compiles one .gitignore-style pattern line, scoped
+// under base (a "/"-separated dir relative to LocalPath, or "" for the
+// sync root). Blank lines and comments yield a nil rule.
+func compileFilterRule(base, pattern string) (*filterRule, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return nil, nil
+	}
+
+	negate := strings.HasPrefix(pattern, "!")
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	if dirOnly {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	trimmed := strings.TrimPrefix(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	re, err := regexp.Compile(globToRegex(trimmed))
+	if err != nil {
+		return nil, err
+	}
+
+	return &filterRule{
+		negate:   negate,
+		dirOnly:  dirOnly,
+		anchored: anchored,
+		base:     base,
+		segments: strings.Split(trimmed, "/"),
+		re:       re,
+	}, nil
+}
+
+// globToRegex translates a single gitignore-style glob (no leading
+// "/", no trailing "/", no leading "!") into an anchored regex.
+func globToRegex(pattern string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			if i+2 < len(runes) && runes[i+2] == '/' {
+				sb.WriteString("(.*/)?")
+				i += 2
+			} else {
+				sb.WriteString(".*")
+				i++
+			}
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' && i+1 < len(runes):
+			sb.WriteString(regexp.QuoteMeta(string(runes[i+1])))
+			i++
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// path2Dir returns the parent of a "/"-separated relative path, or ""
+// if path has no directory component.
+func path2Dir(relPath string) string {
+	idx := strings.LastIndex(relPath, "/")
+	if idx < 0 {
+		return ""
+	}
+	return relPath[:idx]
+}
+
+// withinDir reports whether path is dir itself or nested under it. An
+// empty dir matches every path (the walk root).
+func withinDir(dir, path string) bool {
+	if dir == "" {
+		return true
+	}
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// filterSet is an ordered collection of filterRules; as in gitignore,
+// the last matching rule wins.
+type filterSet struct {
+	rules []*filterRule
+}
+
+func newFilterSet(rules ...*filterRule) *filterSet {
+	fs := &filterSet{}
+	fs.add(rules...)
+	return fs
+}
+
+func (fs *filterSet) add(rules ...*filterRule) {
+	for _, r := range rules {
+		if r != nil {
+			fs.rules = append(fs.rules, r)
+		}
+	}
+}
+
+func (fs *filterSet) empty() bool {
+	return fs == nil || len(fs.rules) == 0
+}
+
+// ignored reports whether relPath should be excluded per this set.
+func (fs *filterSet) ignored(relPath string, isDir bool) bool {
+	if fs == nil {
+		return false
+	}
+
+	ignored := false
+	for _, r := range fs.rules {
+		if r.matches(relPath, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// compileFilterRules compiles a list of raw patterns scoped under base.
+func compileFilterRules(base string, patterns []string) ([]*filterRule, error) {
+	var rules []*filterRule
+	for _, pattern := range patterns {
+		rule, err := compileFilterRule(base, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// loadIgnoreFile reads a .gitignore-style ignore file, if present,
+// returning its compiled rules scoped under base. A missing file is
+// not an error.
+func loadIgnoreFile(path, base string) ([]*filterRule, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return compileFilterRules(base, patterns)
+}
+
+// includeCouldMatchDir reports whether any include pattern could still
+// match a path under dirRelPath, so that collectLocalFiles only prunes
+// a directory (via filepath.SkipDir) when none of its descendants can
+// possibly satisfy Include. Unanchored patterns (no "/") can match a
+// basename at any depth, so they always pass. Anchored patterns are
+// compared segment by segment against dirRelPath: a "**" segment, or
+// running out of either side's segments, means the pattern could still
+// match something further down.
+func includeCouldMatchDir(rules []*filterRule, dirRelPath string) bool {
+	if len(rules) == 0 {
+		return true
+	}
+
+	dirSegs := strings.Split(dirRelPath, "/")
+	for _, r := range rules {
+		if !r.anchored {
+			return true
+		}
+
+		rel := dirRelPath
+		if r.base != "" {
+			if r.base == dirRelPath || strings.HasPrefix(r.base+"/", dirRelPath+"/") {
+				return true // dirRelPath is an ancestor of (or equal to) the rule's own base
+			}
+			if !strings.HasPrefix(dirRelPath, r.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(dirRelPath, r.base+"/")
+		}
+
+		relSegs := dirSegs
+		if rel != dirRelPath {
+			relSegs = strings.Split(rel, "/")
+		}
+		if segmentsCouldMatch(r.segments, relSegs) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// segmentsCouldMatch reports whether patSegs could still match a path
+// that starts with dirSegs, comparing as many leading segments as both
+// sides have.
+func segmentsCouldMatch(patSegs, dirSegs []string) bool {
+	n := len(patSegs)
+	if len(dirSegs) < n {
+		n = len(dirSegs)
+	}
+
+	for i := 0; i < n; i++ {
+		if patSegs[i] == "**" {
+			return true
+		}
+		re, err := regexp.Compile(globToRegex(patSegs[i]))
+		if err != nil || !re.MatchString(dirSegs[i]) {
+			return false
+		}
+	}
+	return true
+}
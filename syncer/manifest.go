@@ -0,0 +1,144 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestFileName is the sidecar manifest kept at the root of
+// RemotePath when ManifestMode is enabled, and always in
+// "bidirectional" mode.
+const manifestFileName = ".fileuploader-manifest.json"
+
+// ManifestEntry records the last-synced state of one file.
+type ManifestEntry struct {
+	Size    int64
+	ModTime time.Time
+	Hash    string
+}
+
+// Manifest maps a file's RelPath to its last-synced state.
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+}
+
+// loadRemoteManifest reads and parses the manifest at RemotePath. A
+// missing manifest (e.g. the first sync) is not an error; it returns
+// an empty Manifest so every local file is treated as changed.
+func (s *Syncer) loadRemoteManifest() (*Manifest, error) {
+	manifestPath := filepath.Join(s.remotePath, manifestFileName)
+
+	remoteFile, err := s.sftpClient().Open(manifestPath)
+	if err != nil {
+		s.logger.Debugf("No remote manifest found at %s, treating all files as changed", manifestPath)
+		return &Manifest{Files: make(map[string]ManifestEntry)}, nil
+	}
+	defer remoteFile.Close()
+
+	data, err := io.ReadAll(remoteFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %v", err)
+	}
+
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]ManifestEntry)
+	}
+
+	return &manifest, nil
+}
+
+// saveRemoteManifest writes manifest back to RemotePath, overwriting
+// any existing one.
+func (s *Syncer) saveRemoteManifest(manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+
+	manifestPath := filepath.Join(s.remotePath, manifestFileName)
+	remoteFile, err := s.sftpClient().Create(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to create remote manifest: %v", err)
+	}
+	defer remoteFile.Close()
+
+	if _, err := remoteFile.Write(data); err != nil {
+		return fmt.Errorf("failed to write remote manifest: %v", err)
+	}
+
+	return nil
+}
+
+// changedFiles returns the entries of localFiles whose hash doesn't
+// match the manifest's recorded hash (including files missing from the
+// manifest entirely). Directories always pass through, since uploadFiles
+// still needs to MkdirAll them.
+func (m *Manifest) changedFiles(localFiles []FileInfo) []FileInfo {
+	var changed []FileInfo
+
+	for _, file := range localFiles {
+		if file.IsDir {
+			changed = append(changed, file)
+			continue
+		}
+
+		entry, ok := m.Files[file.RelPath]
+		if !ok || entry.Hash != file.Hash {
+			changed = append(changed, file)
+		}
+	}
+
+	return changed
+}
+
+// update records the current state of every local file into the
+// manifest, dropping entries for files removed locally.
+func (m *Manifest) update(localFiles []FileInfo) {
+	fresh := make(map[string]ManifestEntry, len(localFiles))
+
+	for _, file := range localFiles {
+		if file.IsDir {
+			continue
+		}
+		fresh[file.RelPath] = ManifestEntry{
+			Size:    file.Size,
+			ModTime: file.ModTime,
+			Hash:    file.Hash,
+		}
+	}
+
+	m.Files = fresh
+}
+
+// hashFile computes the hex-encoded content hash of path using algo.
+// Only "sha256" is currently supported.
+func hashFile(path, algo string) (string, error) {
+	switch algo {
+	case "sha256", "":
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+
+		return hex.EncodeToString(h.Sum(nil)), nil
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm: %s", algo)
+	}
+}
@@ -0,0 +1,109 @@
+package syncer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/pkg/sftp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// hostConn bundles the SSH/SFTP clients dialed for one fan-out target.
+type hostConn struct {
+	host       string
+	sshClient  *ssh.Client
+	sftpClient *sftp.Client
+}
+
+// MultiSyncer fans a single sync job out to multiple remote hosts in
+// parallel, one Syncer per host sharing a combined progress display.
+type MultiSyncer struct {
+	conns      []hostConn
+	syncConfig SyncConfig
+	logger     *logrus.Logger
+}
+
+// NewMulti dials every host in syncConfig.Targets and returns a
+// MultiSyncer ready to sync LocalPath to each of them. If any host
+// fails to dial, already-opened connections are closed and an error is
+// returned.
+func NewMulti(syncConfig SyncConfig, logger *logrus.Logger) (*MultiSyncer, error) {
+	if len(syncConfig.Targets) == 0 {
+		return nil, fmt.Errorf("no targets configured for multi-host sync")
+	}
+
+	conns := make([]hostConn, 0, len(syncConfig.Targets))
+	for _, target := range syncConfig.Targets {
+		sshClient, sftpClient, err := ConnectSSHWithConfig(target)
+		if err != nil {
+			closeAll(conns)
+			return nil, fmt.Errorf("failed to connect to %s: %v", target.Host, err)
+		}
+		conns = append(conns, hostConn{host: target.Host, sshClient: sshClient, sftpClient: sftpClient})
+	}
+
+	return &MultiSyncer{conns: conns, syncConfig: syncConfig, logger: logger}, nil
+}
+
+// Close closes every host's SSH and SFTP client.
+func (m *MultiSyncer) Close() {
+	closeAll(m.conns)
+}
+
+func closeAll(conns []hostConn) {
+	for _, c := range conns {
+		if c.sftpClient != nil {
+			c.sftpClient.Close()
+		}
+		if c.sshClient != nil {
+			c.sshClient.Close()
+		}
+	}
+}
+
+// Sync runs one Syncer per host concurrently, combining their progress
+// bars into a single pb.Pool, and returns the per-host errors so
+// partial failures are actionable.
+func (m *MultiSyncer) Sync() map[string]error {
+	syncers := make(map[string]*Syncer, len(m.conns))
+	bars := make([]*pb.ProgressBar, 0, len(m.conns))
+
+	for _, c := range m.conns {
+		sy := New(c.sftpClient, m.syncConfig, m.logger)
+		sy.SetHost(c.host)
+
+		bar := newProgressBar(0)
+		bar.SetTemplateString(fmt.Sprintf(`{{ "%s" | green }} {{bar . }} {{percent . }} {{speed . }} {{counters . }}`, c.host))
+		sy.SetBar(bar)
+
+		syncers[c.host] = sy
+		bars = append(bars, bar)
+	}
+
+	pool := pb.NewPool(bars...)
+	if err := pool.Start(); err != nil {
+		m.logger.Warnf("Failed to start progress pool: %v", err)
+	}
+	defer pool.Stop()
+
+	results := make(map[string]error, len(syncers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for host, sy := range syncers {
+		wg.Add(1)
+		go func(host string, sy *Syncer) {
+			defer wg.Done()
+			err := sy.Sync()
+
+			mu.Lock()
+			results[host] = err
+			mu.Unlock()
+		}(host, sy)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,193 @@
+package syncer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// pullSync mirrors RemotePath down to LocalPath: the reverse of
+// fullSync/incrementalSync, which only ever push.
+func (s *Syncer) pullSync() error {
+	s.logger.Info("Performing pull synchronization...")
+
+	remoteFiles, err := s.collectRemoteFiles()
+	if err != nil {
+		return fmt.Errorf("failed to collect remote files: %v", err)
+	}
+
+	if s.dryRun {
+		return s.logDryRun("pull", remoteFiles)
+	}
+
+	s.totalSize = 0
+	for _, file := range remoteFiles {
+		if !file.IsDir {
+			s.totalSize += file.Size
+		}
+	}
+
+	s.prepareBar()
+
+	if err := os.MkdirAll(s.localPath, 0755); err != nil {
+		s.finishBar()
+		return fmt.Errorf("failed to create local directory: %v", err)
+	}
+
+	if err := s.downloadFiles(remoteFiles); err != nil {
+		s.finishBar()
+		return fmt.Errorf("failed to download files: %v", err)
+	}
+
+	s.finishBar()
+	return nil
+}
+
+// collectRemoteFiles walks RemotePath over SFTP and collects file
+// information relative to it. Path holds the remote absolute path, so
+// downloadFile can open it directly.
+func (s *Syncer) collectRemoteFiles() ([]FileInfo, error) {
+	var files []FileInfo
+
+	s.logger.Infof("Collecting remote files from: %s", s.remotePath)
+	walker := s.sftpClient().Walk(s.remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, fmt.Errorf("failed to walk remote directory: %v", err)
+		}
+
+		path := walker.Path()
+		relPath, err := filepath.Rel(s.remotePath, path)
+		if err != nil {
+			return nil, err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath == "." {
+			continue
+		}
+
+		info := walker.Stat()
+		files = append(files, FileInfo{
+			Path:    path,
+			RelPath: relPath,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   info.IsDir(),
+		})
+	}
+
+	s.logger.Infof("Found %d remote files/directories", len(files))
+	return files, nil
+}
+
+// downloadFiles downloads files (whose Path is a remote path, as
+// produced by collectRemoteFiles) to LocalPath using a worker pool.
+func (s *Syncer) downloadFiles(files []FileInfo) error {
+	jobs := make(chan FileInfo, len(files))
+	errors := make(chan error, len(files))
+
+	bufPool := sync.Pool{
+		New: func() interface{} {
+			return make([]byte, 32*1024)
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				if err := s.ctx.Err(); err != nil {
+					errors <- err
+					continue
+				}
+
+				file := file
+				err := s.withRetry(func() error {
+					if file.IsDir {
+						localDirPath := filepath.Join(s.localPath, file.RelPath)
+						return os.MkdirAll(localDirPath, 0755)
+					}
+					return s.downloadFile(file, &bufPool)
+				})
+				if err != nil {
+					errors <- fmt.Errorf("failed to sync %s: %v", file.RelPath, err)
+				}
+			}
+		}()
+	}
+
+	for _, file := range files {
+		jobs <- file
+	}
+	close(jobs)
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		if err != nil {
+			return fmt.Errorf("worker error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// downloadFile downloads a single remote file (file.Path) to its
+// corresponding path under LocalPath.
+func (s *Syncer) downloadFile(file FileInfo, bufPool *sync.Pool) error {
+	remoteFile, err := s.sftpClient().Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer remoteFile.Close()
+
+	localFilePath := filepath.Join(s.localPath, file.RelPath)
+	localDir := filepath.Dir(localFilePath)
+
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %v", err)
+	}
+
+	localFile, err := os.Create(localFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %v", err)
+	}
+	defer localFile.Close()
+
+	buf := bufPool.Get().([]byte)
+	defer bufPool.Put(buf)
+
+	for {
+		n, err := remoteFile.Read(buf)
+		if n > 0 {
+			if _, writeErr := localFile.Write(buf[:n]); writeErr != nil {
+				return fmt.Errorf("failed to write to local file: %v", writeErr)
+			}
+
+			s.mutex.Lock()
+			s.syncedSize += int64(n)
+			s.bar.SetCurrent(s.syncedSize)
+			s.mutex.Unlock()
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("failed to read remote file: %v", err)
+		}
+	}
+
+	if err := os.Chtimes(localFilePath, time.Now(), file.ModTime); err != nil {
+		s.logger.Warnf("Failed to set modification time for %s: %v", localFilePath, err)
+	}
+
+	s.logger.Debugf("Downloaded: %s (%d bytes)", file.RelPath, file.Size)
+	return nil
+}
@@ -0,0 +1,160 @@
+package syncer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// tailVerifySize is how many trailing bytes are hashed on both sides
+// before resuming an upload, to catch a partial remote file that
+// doesn't actually match the local file up to the resume offset.
+const tailVerifySize = 4096
+
+// resumableUploadFile uploads a single file, continuing from wherever
+// the remote copy and this sync's checkpoint last left off instead of
+// restarting from zero.
+func (s *Syncer) resumableUploadFile(file FileInfo, remoteBasePath string, bufPool *sync.Pool) error {
+	remoteFilePath := filepath.Join(remoteBasePath, file.RelPath)
+	remoteDir := filepath.Dir(remoteFilePath)
+
+	if err := s.sftpClient().MkdirAll(remoteDir); err != nil {
+		return fmt.Errorf("failed to create remote directory: %v", err)
+	}
+
+	localFile, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer localFile.Close()
+
+	// O_RDWR, not O_WRONLY: verifyTail below reads back the trailing
+	// bytes of this same handle, and most SFTP servers reject a read on
+	// a handle opened write-only.
+	remoteFile, err := s.sftpClient().OpenFile(remoteFilePath, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer remoteFile.Close()
+
+	offset := s.checkpoint.offset(file.RelPath)
+	if remoteInfo, err := s.sftpClient().Stat(remoteFilePath); err == nil && remoteInfo.Size() < offset {
+		offset = remoteInfo.Size()
+	}
+	if offset > file.Size {
+		offset = 0
+	}
+
+	if offset > 0 {
+		ok, err := verifyTail(localFile, remoteFile, offset)
+		if err != nil {
+			return fmt.Errorf("failed to verify tail: %v", err)
+		}
+		if !ok {
+			s.logger.Warnf("Tail mismatch for %s, restarting upload from scratch", file.RelPath)
+			offset = 0
+			if err := remoteFile.Truncate(0); err != nil {
+				return fmt.Errorf("failed to truncate remote file: %v", err)
+			}
+		}
+	}
+
+	if _, err := localFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek local file: %v", err)
+	}
+	if _, err := remoteFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek remote file: %v", err)
+	}
+
+	s.mutex.Lock()
+	s.syncedSize += offset
+	s.bar.SetCurrent(s.syncedSize)
+	s.mutex.Unlock()
+
+	buf := bufPool.Get().([]byte)
+	defer bufPool.Put(buf)
+
+	written := offset
+	lastFlush := offset
+
+	for {
+		n, err := localFile.Read(buf)
+		if n > 0 {
+			if _, writeErr := remoteFile.Write(buf[:n]); writeErr != nil {
+				return fmt.Errorf("failed to write to remote file: %v", writeErr)
+			}
+			written += int64(n)
+
+			s.mutex.Lock()
+			s.syncedSize += int64(n)
+			s.bar.SetCurrent(s.syncedSize)
+			s.mutex.Unlock()
+
+			if written-lastFlush >= checkpointFlushInterval {
+				if err := s.checkpoint.set(file.RelPath, written); err != nil {
+					s.logger.Warnf("Failed to persist checkpoint for %s: %v", file.RelPath, err)
+				}
+				lastFlush = written
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("failed to read local file: %v", err)
+		}
+	}
+
+	// The remote file was opened without O_TRUNC so a partial write can
+	// resume in place; if it was previously larger than the local
+	// source (a shrunk file, or a stale upload with no matching
+	// checkpoint), trim it down to what was actually written.
+	if err := remoteFile.Truncate(written); err != nil {
+		return fmt.Errorf("failed to truncate remote file to final size: %v", err)
+	}
+
+	if err := s.checkpoint.clear(file.RelPath); err != nil {
+		s.logger.Warnf("Failed to clear checkpoint for %s: %v", file.RelPath, err)
+	}
+
+	if err := s.sftpClient().Chmod(remoteFilePath, 0644); err != nil {
+		s.logger.Warnf("Failed to set permissions for %s: %v", remoteFilePath, err)
+	}
+
+	if err := s.sftpClient().Chtimes(remoteFilePath, time.Now(), file.ModTime); err != nil {
+		s.logger.Warnf("Failed to set modification time for %s: %v", remoteFilePath, err)
+	}
+
+	s.logger.Debugf("Resumed upload: %s (%d bytes, resumed from %d)", file.RelPath, file.Size, offset)
+	return nil
+}
+
+// verifyTail hashes the trailing tailVerifySize bytes up to offset on
+// both the local and remote file and reports whether they match.
+func verifyTail(localFile *os.File, remoteFile io.ReaderAt, offset int64) (bool, error) {
+	size := int64(tailVerifySize)
+	if offset < size {
+		size = offset
+	}
+	start := offset - size
+
+	localBuf := make([]byte, size)
+	if _, err := localFile.ReadAt(localBuf, start); err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read local tail: %v", err)
+	}
+
+	remoteBuf := make([]byte, size)
+	if _, err := remoteFile.ReadAt(remoteBuf, start); err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read remote tail: %v", err)
+	}
+
+	localSum := sha256.Sum256(localBuf)
+	remoteSum := sha256.Sum256(remoteBuf)
+	return bytes.Equal(localSum[:], remoteSum[:]), nil
+}
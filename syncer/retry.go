@@ -0,0 +1,129 @@
+package syncer
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 500 * time.Millisecond
+
+	// clientDrainGrace is how long the old SSH connection is kept open
+	// after a reconnect before being closed, so in-flight SFTP calls
+	// that already captured the old client via sftpClient() have a
+	// chance to finish instead of having it yanked out from under them.
+	clientDrainGrace = 5 * time.Second
+)
+
+// sftpClient returns the current SFTP client. It's safe to call
+// concurrently with maybeReconnect swapping the client out mid-sync.
+func (s *Syncer) sftpClient() *sftp.Client {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.client
+}
+
+// withRetry calls fn, retrying on transient errors with exponential
+// backoff and jitter, making at most s.retryAttempts attempts in all
+// (the first call included). It gives up
+// immediately on a non-transient error or on context cancellation, and
+// attempts an auto-reconnect between retries when the failure looks
+// like a dropped connection.
+func (s *Syncer) withRetry(fn func() error) error {
+	var lastErr error
+
+	for attempt := 0; attempt < s.retryAttempts; attempt++ {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		if !isTransientError(lastErr) {
+			return lastErr
+		}
+
+		s.maybeReconnect(lastErr)
+
+		if attempt == s.retryAttempts-1 {
+			break
+		}
+
+		delay := s.retryBaseDelay * time.Duration(1<<uint(attempt))
+		delay += time.Duration(rand.Int63n(int64(s.retryBaseDelay) + 1))
+		s.logger.Warnf("Transient error, retrying in %s (attempt %d/%d): %v", delay, attempt+1, s.retryAttempts, lastErr)
+
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+
+	return lastErr
+}
+
+// maybeReconnect re-dials the SSH/SFTP connection when err looks like
+// a dropped connection and a reconnector was configured via
+// NewWithReconnect.
+func (s *Syncer) maybeReconnect(err error) {
+	if s.reconnect == nil || !isTransientError(err) {
+		return
+	}
+
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	s.logger.Warnf("Attempting to reconnect after transient error: %v", err)
+
+	sshClient, sftpClient, dialErr := s.reconnect()
+	if dialErr != nil {
+		s.logger.Warnf("Auto-reconnect failed: %v", dialErr)
+		return
+	}
+
+	oldSSH := s.sshClient
+	s.sshClient = sshClient
+	s.client = sftpClient
+	s.logger.Info("Reconnected SSH/SFTP client after transient failure")
+
+	if oldSSH != nil {
+		time.AfterFunc(clientDrainGrace, func() { oldSSH.Close() })
+	}
+}
+
+// isTransientError reports whether err looks like a recoverable
+// network hiccup (dropped connection, short read) worth retrying,
+// rather than a permanent failure (permission denied, no such file).
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, substr := range []string{"connection reset", "broken pipe", "use of closed network connection", "EOF"} {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,24 +2,54 @@ package syncer
 
 import (
 	"fmt"
+	"net"
+	"os"
 	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
-// ConnectSSH establishes an SSH connection
+// ConnectSSH establishes an SSH connection using a plain password.
+//
+// It is kept for backward compatibility; new callers should prefer
+// ConnectSSHWithConfig, which supports keys, ssh-agent, and known_hosts
+// verification. This helper retains the previous insecure host key
+// behavior.
 func ConnectSSH(host string, port int, user, password string) (*ssh.Client, *sftp.Client, error) {
+	return ConnectSSHWithConfig(SSHConfig{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		Password: password,
+		Insecure: true,
+	})
+}
+
+// ConnectSSHWithConfig establishes an SSH connection using the auth
+// methods and host key policy described by cfg. Auth methods are tried
+// in this order when configured: private key, ssh-agent, password.
+func ConnectSSHWithConfig(cfg SSHConfig) (*ssh.Client, *sftp.Client, error) {
+	authMethods, err := buildAuthMethods(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostKeyCallback, err := buildHostKeyCallback(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	config := &ssh.ClientConfig{
-		User: user,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            cfg.User,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
 		Timeout:         10 * time.Second,
 	}
 
-	address := fmt.Sprintf("%s:%d", host, port)
+	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 
 	client, err := ssh.Dial("tcp", address, config)
 	if err != nil {
@@ -35,3 +65,89 @@ func ConnectSSH(host string, port int, user, password string) (*ssh.Client, *sft
 
 	return client, sftpClient, nil
 }
+
+// buildAuthMethods assembles the ordered list of SSH auth methods
+// requested by cfg: private key, ssh-agent, then password.
+func buildAuthMethods(cfg SSHConfig) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if cfg.KeyFile != "" {
+		signer, err := loadPrivateKey(cfg.KeyFile, cfg.KeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key %s: %v", cfg.KeyFile, err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if cfg.UseAgent {
+		signers, err := agentSigners()
+		if err != nil {
+			return nil, fmt.Errorf("failed to use ssh-agent: %v", err)
+		}
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			return signers, nil
+		}))
+	}
+
+	if cfg.Password != "" {
+		methods = append(methods, ssh.Password(cfg.Password))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no SSH auth method configured: set Password, KeyFile, and/or UseAgent")
+	}
+
+	return methods, nil
+}
+
+// loadPrivateKey reads and parses a private key file, decrypting it
+// with passphrase if the key is encrypted and passphrase is non-empty.
+func loadPrivateKey(keyFile, passphrase string) (ssh.Signer, error) {
+	keyData, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+
+	if passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(keyData, []byte(passphrase))
+	}
+
+	return ssh.ParsePrivateKey(keyData)
+}
+
+// agentSigners connects to the running ssh-agent via SSH_AUTH_SOCK and
+// returns the signers it offers.
+func agentSigners() ([]ssh.Signer, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh-agent: %v", err)
+	}
+
+	agentClient := agent.NewClient(conn)
+	return agentClient.Signers()
+}
+
+// buildHostKeyCallback builds the host key verification policy
+// requested by cfg. Insecure explicitly opts in to skipping host key
+// verification; otherwise KnownHostsFile is required.
+func buildHostKeyCallback(cfg SSHConfig) (ssh.HostKeyCallback, error) {
+	if cfg.Insecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	if cfg.KnownHostsFile == "" {
+		return nil, fmt.Errorf("KnownHostsFile must be set unless Insecure is true")
+	}
+
+	callback, err := knownhosts.New(cfg.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts file %s: %v", cfg.KnownHostsFile, err)
+	}
+
+	return callback, nil
+}
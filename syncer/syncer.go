@@ -1,6 +1,7 @@
 package syncer
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/cheggaaa/pb/v3"
 	"github.com/pkg/sftp"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
 )
 
 // SSHConfig holds SSH connection parameters
@@ -19,6 +21,21 @@ type SSHConfig struct {
 	Port     int
 	User     string
 	Password string
+
+	// KeyFile, if set, authenticates with this private key file.
+	KeyFile string
+	// KeyPassphrase decrypts KeyFile when it is passphrase-protected.
+	KeyPassphrase string
+	// UseAgent authenticates using the signers offered by ssh-agent,
+	// discovered via the SSH_AUTH_SOCK environment variable.
+	UseAgent bool
+
+	// KnownHostsFile verifies the remote host key against entries in
+	// this known_hosts file. Required unless Insecure is set.
+	KnownHostsFile string
+	// Insecure opts in to skipping host key verification. Keep this
+	// false in production; it exists for local testing only.
+	Insecure bool
 }
 
 // SyncConfig holds synchronization parameters
@@ -27,6 +44,74 @@ type SyncConfig struct {
 	RemotePath string
 	Mode       string
 	Workers    int
+
+	// Targets, if non-empty, fans this sync out to multiple remote
+	// hosts via NewMulti instead of a single pre-connected client.
+	Targets []SSHConfig
+
+	// DeltaMode enables rsync-style delta transfer during incremental
+	// sync: only the bytes that changed within a file are sent, using
+	// rolling+strong block checksums instead of a whole-file upload.
+	DeltaMode bool
+	// DeltaBlockSize is the block size used for delta signatures.
+	// Defaults to 64 KiB when zero.
+	DeltaBlockSize int64
+
+	// ManifestMode enables content-hash based change detection during
+	// incremental sync: a manifest file on the remote side tracks each
+	// file's hash, and only files whose hash differs are uploaded,
+	// instead of every local file.
+	ManifestMode bool
+	// HashAlgo selects the hash used for the manifest. Defaults to
+	// "sha256" when empty.
+	HashAlgo string
+
+	// Resume continues interrupted file uploads from their last
+	// confirmed byte instead of restarting from zero, using a
+	// per-file checkpoint stored under CheckpointDir.
+	Resume bool
+	// CheckpointDir holds resume checkpoints. Defaults to
+	// ~/.fileuploader/state when empty.
+	CheckpointDir string
+
+	// ChunkedUploadThreshold, when non-zero, enables parallel-range
+	// uploads for any file at or above this size: the file is
+	// pre-allocated remotely and written by ChunkConcurrency workers
+	// via WriteAt instead of a single sequential stream. When a file is
+	// written straight to RemotePath, DeltaMode and Resume take
+	// precedence and such files are not chunked.
+	ChunkedUploadThreshold int64
+	// ChunkConcurrency is the number of parallel WriteAt workers per
+	// chunked file. Defaults to 4 when zero.
+	ChunkConcurrency int
+
+	// ConflictPolicy resolves which side wins when "bidirectional" mode
+	// finds a relative path present on both sides that no longer matches
+	// the last-sync manifest (or, with no manifest entry, whose local and
+	// remote size/mtime differ), whichever side changed: "newer"
+	// (default), "larger", "local", "remote", or "rename" (keep both).
+	ConflictPolicy string
+	// DryRun logs the planned action set for the chosen mode without
+	// mutating either side.
+	DryRun bool
+
+	// RetryAttempts is how many times in all a file transfer is
+	// attempted (the first try included) when it keeps failing with a
+	// transient SFTP error. Defaults to 3.
+	RetryAttempts int
+	// RetryBaseDelay is the base exponential backoff delay between
+	// retries (jitter is added on top). Defaults to 500ms.
+	RetryBaseDelay time.Duration
+
+	// Include, if non-empty, restricts collectLocalFiles to paths
+	// matching at least one of these .gitignore-style patterns.
+	Include []string
+	// Exclude removes paths matching any of these .gitignore-style
+	// patterns, applied after Include.
+	Exclude []string
+	// IgnoreFile names a per-directory ignore file (.gitignore syntax)
+	// honored throughout the local tree. Defaults to ".syncignore".
+	IgnoreFile string
 }
 
 // FileInfo represents file information for synchronization
@@ -36,41 +121,227 @@ type FileInfo struct {
 	Size    int64
 	ModTime time.Time
 	IsDir   bool
+	// Hash is the hex-encoded content hash, populated when ManifestMode
+	// is enabled and for local files in "bidirectional" mode.
+	Hash string
 }
 
 // Syncer handles file synchronization
 type Syncer struct {
 	client     *sftp.Client
+	sshClient  *ssh.Client
+	host       string // fan-out target identity, set via SetHost; disambiguates checkpoints under MultiSyncer
 	localPath  string
 	remotePath string
 	mode       string
 	workers    int
 	bar        *pb.ProgressBar
+	ownsBar    bool
 	totalSize  int64
 	syncedSize int64
 	mutex      sync.Mutex
 	logger     *logrus.Logger
+
+	deltaMode      bool
+	deltaBlockSize int64
+
+	manifestMode bool
+	hashAlgo     string
+
+	resume        bool
+	checkpointDir string
+	checkpoint    *Checkpoint
+
+	chunkThreshold   int64
+	chunkConcurrency int
+
+	conflictPolicy string
+	dryRun         bool
+
+	ctx            context.Context
+	retryAttempts  int
+	retryBaseDelay time.Duration
+	reconnect      func() (*ssh.Client, *sftp.Client, error)
+	clientMu       sync.RWMutex
+
+	includeRules []*filterRule
+	includeSet   *filterSet
+	excludeRules []*filterRule
+	ignoreFile   string
 }
 
 // New creates a new Syncer instance with direct configuration
 func New(client *sftp.Client, syncConfig SyncConfig, logger *logrus.Logger) *Syncer {
+	blockSize := syncConfig.DeltaBlockSize
+	if blockSize <= 0 {
+		blockSize = defaultDeltaBlockSize
+	}
+
+	hashAlgo := syncConfig.HashAlgo
+	if hashAlgo == "" {
+		hashAlgo = "sha256"
+	}
+
+	conflictPolicy := syncConfig.ConflictPolicy
+	if conflictPolicy == "" {
+		conflictPolicy = conflictNewer
+	}
+
+	retryAttempts := syncConfig.RetryAttempts
+	if retryAttempts <= 0 {
+		retryAttempts = defaultRetryAttempts
+	}
+	retryBaseDelay := syncConfig.RetryBaseDelay
+	if retryBaseDelay <= 0 {
+		retryBaseDelay = defaultRetryBaseDelay
+	}
+
+	ignoreFile := syncConfig.IgnoreFile
+	if ignoreFile == "" {
+		ignoreFile = defaultIgnoreFile
+	}
+
+	includeRules, err := compileFilterRules("", syncConfig.Include)
+	if err != nil {
+		logger.Warnf("Ignoring invalid Include pattern(s): %v", err)
+	}
+	excludeRules, err := compileFilterRules("", syncConfig.Exclude)
+	if err != nil {
+		logger.Warnf("Ignoring invalid Exclude pattern(s): %v", err)
+	}
+
 	return &Syncer{
-		client:     client,
-		localPath:  syncConfig.LocalPath,
-		remotePath: syncConfig.RemotePath,
-		mode:       syncConfig.Mode,
-		workers:    syncConfig.Workers,
-		logger:     logger,
+		client:         client,
+		localPath:      syncConfig.LocalPath,
+		remotePath:     syncConfig.RemotePath,
+		mode:           syncConfig.Mode,
+		workers:        syncConfig.Workers,
+		logger:         logger,
+		deltaMode:      syncConfig.DeltaMode,
+		deltaBlockSize: blockSize,
+		manifestMode:   syncConfig.ManifestMode,
+		hashAlgo:       hashAlgo,
+		resume:         syncConfig.Resume,
+		checkpointDir:  syncConfig.CheckpointDir,
+
+		chunkThreshold:   syncConfig.ChunkedUploadThreshold,
+		chunkConcurrency: syncConfig.ChunkConcurrency,
+
+		conflictPolicy: conflictPolicy,
+		dryRun:         syncConfig.DryRun,
+
+		ctx:            context.Background(),
+		retryAttempts:  retryAttempts,
+		retryBaseDelay: retryBaseDelay,
+
+		includeRules: includeRules,
+		includeSet:   newFilterSet(includeRules...),
+		excludeRules: excludeRules,
+		ignoreFile:   ignoreFile,
 	}
 }
 
+// NewWithReconnect creates a Syncer like New, additionally configuring
+// it to auto-reconnect using sshConfig when a transfer hits a dropped
+// connection. sshClient/sftpClient should already be dialed, e.g. via
+// ConnectSSHWithConfig(sshConfig).
+func NewWithReconnect(sshClient *ssh.Client, sftpClient *sftp.Client, sshConfig SSHConfig, syncConfig SyncConfig, logger *logrus.Logger) *Syncer {
+	s := New(sftpClient, syncConfig, logger)
+	s.sshClient = sshClient
+	s.reconnect = func() (*ssh.Client, *sftp.Client, error) {
+		return ConnectSSHWithConfig(sshConfig)
+	}
+	return s
+}
+
+// ensureCheckpoint lazily loads this sync's checkpoint from disk the
+// first time it's needed.
+func (s *Syncer) ensureCheckpoint() error {
+	if !s.resume || s.checkpoint != nil {
+		return nil
+	}
+
+	cp, err := loadCheckpoint(s.checkpointDir, computeSyncID(s.host, s.localPath, s.remotePath))
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %v", err)
+	}
+
+	s.checkpoint = cp
+	return nil
+}
+
+// SetBar assigns an externally managed progress bar to the syncer,
+// e.g. one bar of a pb.Pool driving a multi-host sync. The caller
+// remains responsible for starting and stopping the bar/pool.
+func (s *Syncer) SetBar(bar *pb.ProgressBar) {
+	s.bar = bar
+	s.ownsBar = false
+}
+
+// SetHost identifies which fan-out target this syncer is driving, e.g.
+// one host of a MultiSyncer. It must be set before Sync runs when
+// Resume is enabled and multiple Syncers share the same LocalPath and
+// RemotePath, so each host gets its own checkpoint file instead of
+// racing on one shared by sync-id alone.
+func (s *Syncer) SetHost(host string) {
+	s.host = host
+}
+
+// prepareBar ensures s.bar is sized for s.totalSize, creating and
+// starting a standalone bar if the caller didn't supply one via SetBar.
+func (s *Syncer) prepareBar() {
+	if s.bar == nil {
+		s.bar = newProgressBar(s.totalSize)
+		s.bar.Start()
+		s.ownsBar = true
+		return
+	}
+
+	s.bar.SetTotal(s.totalSize)
+}
+
+// finishBar finishes the bar only if this syncer created it; bars
+// supplied via SetBar are finished by their owner (e.g. the pb.Pool).
+func (s *Syncer) finishBar() {
+	if s.ownsBar {
+		s.bar.Finish()
+	}
+}
+
+// newProgressBar builds a progress bar configured with this package's
+// standard template, without starting it.
+func newProgressBar(total int64) *pb.ProgressBar {
+	bar := pb.New64(total)
+	bar.Set(pb.Bytes, true)
+	bar.SetWidth(80)
+	bar.SetRefreshRate(time.Second)
+	bar.Set(pb.Terminal, false)
+	bar.Set(pb.Static, false)
+	bar.SetTemplateString(`\rSync Progress: {{bar . }} {{percent . }} {{speed . }} {{counters . }}`)
+	return bar
+}
+
 // Sync performs the file synchronization based on the mode
 func (s *Syncer) Sync() error {
+	return s.SyncContext(context.Background())
+}
+
+// SyncContext performs the file synchronization like Sync, but honors
+// cancellation: when ctx is done, in-flight workers stop dispatching
+// new transfers and in-progress cleanup (e.g. the temp/backup
+// directories in fullSync) still runs.
+func (s *Syncer) SyncContext(ctx context.Context) error {
+	s.ctx = ctx
+
 	switch s.mode {
 	case "full":
 		return s.fullSync()
 	case "incremental":
 		return s.incrementalSync()
+	case "pull":
+		return s.pullSync()
+	case "bidirectional":
+		return s.bidirectionalSync()
 	default:
 		return fmt.Errorf("unsupported sync mode: %s", s.mode)
 	}
@@ -86,6 +357,10 @@ func (s *Syncer) fullSync() error {
 		return fmt.Errorf("failed to collect local files: %v", err)
 	}
 
+	if s.dryRun {
+		return s.logDryRun("full", localFiles)
+	}
+
 	// Calculate total size for progress bar
 	s.totalSize = 0
 	for _, file := range localFiles {
@@ -95,30 +370,24 @@ func (s *Syncer) fullSync() error {
 	}
 
 	// Create progress bar
-	s.bar = pb.Full.Start64(s.totalSize)
-	s.bar.Set(pb.Bytes, true)
-	s.bar.SetWidth(80)
-	s.bar.SetRefreshRate(time.Second)
-	s.bar.Set(pb.Terminal, false)
-	s.bar.Set(pb.Static, false)
-	s.bar.SetTemplateString(`\rSync Progress: {{bar . }} {{percent . }} {{speed . }} {{counters . }}`)
+	s.prepareBar()
 
 	// Create temporary remote directory
 	tempRemotePath := filepath.Join(filepath.Dir(s.remotePath), ".sync_tmp_"+time.Now().Format("20060102_150405"))
 	s.logger.Infof("Creating temporary directory: %s", tempRemotePath)
 
-	if err := s.client.MkdirAll(tempRemotePath); err != nil {
-		s.bar.Finish()
+	if err := s.sftpClient().MkdirAll(tempRemotePath); err != nil {
+		s.finishBar()
 		return fmt.Errorf("failed to create temporary directory: %v", err)
 	}
 
 	// Ensure cleanup of temporary directory
 	defer func() {
 		s.logger.Infof("Cleaning up temporary directory: %s", tempRemotePath)
-		if err := s.client.RemoveDirectory(tempRemotePath); err != nil {
+		if err := s.sftpClient().RemoveDirectory(tempRemotePath); err != nil {
 			s.logger.Warnf("Failed to remove temporary directory: %v", err)
 		}
-		s.bar.Finish()
+		s.finishBar()
 	}()
 
 	// Upload files using worker pool
@@ -130,19 +399,19 @@ func (s *Syncer) fullSync() error {
 	backupPath := s.remotePath + ".bak_" + time.Now().Format("20060102_150405")
 	s.logger.Infof("Creating backup at: %s", backupPath)
 
-	if _, err := s.client.Stat(s.remotePath); err == nil {
+	if _, err := s.sftpClient().Stat(s.remotePath); err == nil {
 		// Remote path exists, rename it to backup
-		if err := s.client.Rename(s.remotePath, backupPath); err != nil {
+		if err := s.sftpClient().Rename(s.remotePath, backupPath); err != nil {
 			return fmt.Errorf("failed to create backup: %v", err)
 		}
 	}
 
 	// Rename temporary directory to target directory
 	s.logger.Infof("Renaming %s to %s", tempRemotePath, s.remotePath)
-	if err := s.client.Rename(tempRemotePath, s.remotePath); err != nil {
+	if err := s.sftpClient().Rename(tempRemotePath, s.remotePath); err != nil {
 		// Try to restore from backup if rename fails
-		if _, backupErr := s.client.Stat(backupPath); backupErr == nil {
-			if restoreErr := s.client.Rename(backupPath, s.remotePath); restoreErr != nil {
+		if _, backupErr := s.sftpClient().Stat(backupPath); backupErr == nil {
+			if restoreErr := s.sftpClient().Rename(backupPath, s.remotePath); restoreErr != nil {
 				return fmt.Errorf("sync failed and restore failed: %v, restore error: %v", err, restoreErr)
 			}
 			return fmt.Errorf("sync failed, restored from backup: %v", err)
@@ -151,9 +420,9 @@ func (s *Syncer) fullSync() error {
 	}
 
 	// Remove backup directory
-	if _, err := s.client.Stat(backupPath); err == nil {
+	if _, err := s.sftpClient().Stat(backupPath); err == nil {
 		s.logger.Infof("Removing backup directory: %s", backupPath)
-		if err := s.client.RemoveDirectory(backupPath); err != nil {
+		if err := s.sftpClient().RemoveDirectory(backupPath); err != nil {
 			s.logger.Warnf("Failed to remove backup directory: %v", err)
 		}
 	}
@@ -171,36 +440,77 @@ func (s *Syncer) incrementalSync() error {
 		return fmt.Errorf("failed to collect local files: %v", err)
 	}
 
+	var manifest *Manifest
+	filesToUpload := localFiles
+
+	if s.manifestMode {
+		manifest, err = s.loadRemoteManifest()
+		if err != nil {
+			return fmt.Errorf("failed to load remote manifest: %v", err)
+		}
+		filesToUpload = manifest.changedFiles(localFiles)
+		s.logger.Infof("Manifest diff: %d/%d files changed", countNonDirs(filesToUpload), countNonDirs(localFiles))
+	}
+
+	if s.dryRun {
+		return s.logDryRun("incremental", filesToUpload)
+	}
+
 	// Calculate total size for progress bar
 	s.totalSize = 0
-	for _, file := range localFiles {
+	for _, file := range filesToUpload {
 		if !file.IsDir {
 			s.totalSize += file.Size
 		}
 	}
 
 	// Create progress bar
-	s.bar = pb.Full.Start64(s.totalSize)
-	s.bar.Set(pb.Bytes, true)
-	s.bar.SetWidth(80)
-	s.bar.SetRefreshRate(time.Second)
-	s.bar.Set(pb.Terminal, false)
-	s.bar.Set(pb.Static, false)
-	s.bar.SetTemplateString(`\rSync Progress: {{bar . }} {{percent . }} {{speed . }} {{counters . }}`)
+	s.prepareBar()
 
 	// Ensure remote directory exists
-	if err := s.client.MkdirAll(s.remotePath); err != nil {
-		s.bar.Finish()
+	if err := s.sftpClient().MkdirAll(s.remotePath); err != nil {
+		s.finishBar()
 		return fmt.Errorf("failed to create remote directory: %v", err)
 	}
 
 	// Upload files using worker pool
-	if err := s.uploadFiles(localFiles, s.remotePath); err != nil {
-		s.bar.Finish()
+	if err := s.uploadFiles(filesToUpload, s.remotePath); err != nil {
+		s.finishBar()
 		return fmt.Errorf("failed to upload files: %v", err)
 	}
 
-	s.bar.Finish()
+	s.finishBar()
+
+	if s.manifestMode {
+		manifest.update(localFiles)
+		if err := s.saveRemoteManifest(manifest); err != nil {
+			return fmt.Errorf("failed to save remote manifest: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// countNonDirs returns the number of non-directory entries in files.
+func countNonDirs(files []FileInfo) int {
+	count := 0
+	for _, file := range files {
+		if !file.IsDir {
+			count++
+		}
+	}
+	return count
+}
+
+// logDryRun logs the action set a mode would have performed, without
+// mutating either side, and returns the nil error Sync should propagate.
+func (s *Syncer) logDryRun(mode string, files []FileInfo) error {
+	s.logger.Infof("Dry run (%s): %d files would be transferred", mode, countNonDirs(files))
+	for _, file := range files {
+		if !file.IsDir {
+			s.logger.Infof("  would transfer: %s (%d bytes)", file.RelPath, file.Size)
+		}
+	}
 	return nil
 }
 
@@ -208,6 +518,24 @@ func (s *Syncer) incrementalSync() error {
 func (s *Syncer) collectLocalFiles() ([]FileInfo, error) {
 	var files []FileInfo
 
+	// dirIgnores stacks the ignore-file rules picked up at each level of
+	// the walk, innermost last, so a subtree's ignore file only applies
+	// under its own directory.
+	type dirIgnore struct {
+		dir   string
+		rules []*filterRule
+	}
+	var dirIgnores []dirIgnore
+
+	// The walk below skips the root entry, so its ignore file is loaded here.
+	rootRules, loadErr := loadIgnoreFile(filepath.Join(s.localPath, s.ignoreFile), "")
+	if loadErr != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", s.ignoreFile, loadErr)
+	}
+	if len(rootRules) > 0 {
+		dirIgnores = append(dirIgnores, dirIgnore{dir: "", rules: rootRules})
+	}
+
 	s.logger.Infof("Collecting files from: %s", s.localPath)
 	err := filepath.Walk(s.localPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -218,12 +546,55 @@ func (s *Syncer) collectLocalFiles() ([]FileInfo, error) {
 		if err != nil {
 			return err
 		}
+		relPath = filepath.ToSlash(relPath)
 
 		// Skip the root directory itself
 		if relPath == "." {
 			return nil
 		}
 
+		dirRelPath := relPath
+		if !info.IsDir() {
+			dirRelPath = path2Dir(relPath)
+		}
+		for len(dirIgnores) > 0 && !withinDir(dirIgnores[len(dirIgnores)-1].dir, dirRelPath) {
+			dirIgnores = dirIgnores[:len(dirIgnores)-1]
+		}
+
+		excludeSet := newFilterSet(s.excludeRules...)
+		for _, di := range dirIgnores {
+			excludeSet.add(di.rules...)
+		}
+
+		if len(s.includeRules) > 0 {
+			if info.IsDir() {
+				if !includeCouldMatchDir(s.includeRules, relPath) {
+					s.logger.Debugf("Skipping directory (no include match): %s", relPath)
+					return filepath.SkipDir
+				}
+			} else if !s.includeSet.ignored(relPath, false) {
+				return nil
+			}
+		}
+
+		if excludeSet.ignored(relPath, info.IsDir()) {
+			s.logger.Debugf("Skipping excluded path: %s", relPath)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			ignoreRules, loadErr := loadIgnoreFile(filepath.Join(path, s.ignoreFile), relPath)
+			if loadErr != nil {
+				return fmt.Errorf("failed to load %s in %s: %v", s.ignoreFile, relPath, loadErr)
+			}
+			if len(ignoreRules) > 0 {
+				dirIgnores = append(dirIgnores, dirIgnore{dir: relPath, rules: ignoreRules})
+			}
+		}
+
 		files = append(files, FileInfo{
 			Path:    path,
 			RelPath: relPath,
@@ -246,11 +617,68 @@ func (s *Syncer) collectLocalFiles() ([]FileInfo, error) {
 	}
 
 	s.logger.Infof("Found %d files/directories", len(files))
+
+	if s.manifestMode {
+		if err := s.hashFiles(files); err != nil {
+			return nil, fmt.Errorf("failed to hash local files: %v", err)
+		}
+	}
+
 	return files, nil
 }
 
+// hashFiles populates the Hash field of every non-directory entry in
+// files, computing hashes concurrently across a bounded worker pool.
+func (s *Syncer) hashFiles(files []FileInfo) error {
+	workers := s.workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	jobs := make(chan int, len(files))
+	errors := make(chan error, len(files))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				if files[idx].IsDir {
+					continue
+				}
+				hash, err := hashFile(files[idx].Path, s.hashAlgo)
+				if err != nil {
+					errors <- fmt.Errorf("failed to hash %s: %v", files[idx].RelPath, err)
+					continue
+				}
+				files[idx].Hash = hash
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // uploadFiles uploads files using a worker pool
 func (s *Syncer) uploadFiles(files []FileInfo, remoteBasePath string) error {
+	if err := s.ensureCheckpoint(); err != nil {
+		return fmt.Errorf("failed to prepare resume checkpoint: %v", err)
+	}
+
 	// Create channels for work distribution
 	jobs := make(chan FileInfo, len(files))
 	errors := make(chan error, len(files))
@@ -294,25 +722,42 @@ func (s *Syncer) worker(wg *sync.WaitGroup, jobs <-chan FileInfo, errors chan<-
 	defer wg.Done()
 
 	for file := range jobs {
-		if file.IsDir {
-			// Create remote directory
-			remoteDirPath := filepath.Join(remoteBasePath, file.RelPath)
-			s.logger.Debugf("Creating remote directory: %s", remoteDirPath)
-
-			if err := s.client.MkdirAll(remoteDirPath); err != nil {
-				errors <- fmt.Errorf("failed to create remote directory %s: %v", remoteDirPath, err)
-				continue
-			}
-		} else {
-			// Upload file
-			if err := s.uploadFile(file, remoteBasePath, bufPool); err != nil {
-				errors <- fmt.Errorf("failed to upload file %s: %v", file.RelPath, err)
-				continue
-			}
+		if err := s.ctx.Err(); err != nil {
+			errors <- err
+			continue
+		}
+
+		file := file
+		err := s.withRetry(func() error {
+			return s.transferPushFile(file, remoteBasePath, bufPool)
+		})
+		if err != nil {
+			errors <- fmt.Errorf("failed to sync %s: %v", file.RelPath, err)
 		}
 	}
 }
 
+// transferPushFile performs the single local-to-remote transfer for
+// file, picking whichever upload strategy applies.
+func (s *Syncer) transferPushFile(file FileInfo, remoteBasePath string, bufPool *sync.Pool) error {
+	if file.IsDir {
+		remoteDirPath := filepath.Join(remoteBasePath, file.RelPath)
+		s.logger.Debugf("Creating remote directory: %s", remoteDirPath)
+		return s.sftpClient().MkdirAll(remoteDirPath)
+	}
+
+	switch {
+	case s.deltaMode && remoteBasePath == s.remotePath:
+		return s.deltaUploadFile(file, remoteBasePath, bufPool)
+	case s.resume && remoteBasePath == s.remotePath:
+		return s.resumableUploadFile(file, remoteBasePath, bufPool)
+	case s.chunkThreshold > 0 && file.Size >= s.chunkThreshold:
+		return s.chunkedUploadFile(file, remoteBasePath, bufPool)
+	default:
+		return s.uploadFile(file, remoteBasePath, bufPool)
+	}
+}
+
 // uploadFile uploads a single file
 func (s *Syncer) uploadFile(file FileInfo, remoteBasePath string, bufPool *sync.Pool) error {
 	// Open local file
@@ -327,12 +772,12 @@ func (s *Syncer) uploadFile(file FileInfo, remoteBasePath string, bufPool *sync.
 	remoteDir := filepath.Dir(remoteFilePath)
 
 	// Ensure remote directory exists
-	if err := s.client.MkdirAll(remoteDir); err != nil {
+	if err := s.sftpClient().MkdirAll(remoteDir); err != nil {
 		return fmt.Errorf("failed to create remote directory: %v", err)
 	}
 
 	// Create remote file
-	remoteFile, err := s.client.Create(remoteFilePath)
+	remoteFile, err := s.sftpClient().Create(remoteFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to create remote file: %v", err)
 	}
@@ -365,11 +810,11 @@ func (s *Syncer) uploadFile(file FileInfo, remoteBasePath string, bufPool *sync.
 	}
 
 	// Set file permissions and modification time
-	if err := s.client.Chmod(remoteFilePath, 0644); err != nil {
+	if err := s.sftpClient().Chmod(remoteFilePath, 0644); err != nil {
 		s.logger.Warnf("Failed to set permissions for %s: %v", remoteFilePath, err)
 	}
 
-	if err := s.client.Chtimes(remoteFilePath, time.Now(), file.ModTime); err != nil {
+	if err := s.sftpClient().Chtimes(remoteFilePath, time.Now(), file.ModTime); err != nil {
 		s.logger.Warnf("Failed to set modification time for %s: %v", remoteFilePath, err)
 	}
 